@@ -0,0 +1,333 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/sirupsen/logrus"
+)
+
+func NewCommandHandler(redis *redis.Client, bot *tgbotapi.BotAPI) *commandHandler {
+	return &commandHandler{
+		redis:     redis,
+		bot:       bot,
+		owners:    loadIDSetFromEnv("HUDOR_OWNER_IDS"),
+		rateLimit: RateLimit(20, time.Minute),
+	}
+}
+
+type commandHandler struct {
+	redis     *redis.Client
+	bot       *tgbotapi.BotAPI
+	owners    map[int]bool
+	rateLimit Middleware
+}
+
+func (h *commandHandler) isOwner(userID int) bool {
+	return h.owners[userID]
+}
+
+// Handle dispatches a single command message through the middleware
+// chain appropriate for that command.
+func (h *commandHandler) Handle(message tgbotapi.Message) {
+	switch message.Command() {
+	case "gban":
+		h.run(message, h.handleGlobalBan)
+		return
+	case "gunban":
+		h.run(message, h.handleGlobalUnban)
+		return
+	case "globalstats":
+		h.run(message, h.handleGlobalStats)
+		return
+	}
+
+	if !message.Chat.IsSuperGroup() && !message.Chat.IsPrivate() {
+		if _, err := h.bot.Send(hodurOnlyActiveInSuperGroups(message.Chat.ID)); err != nil {
+			logrus.WithField("chat", message.Chat.ID).Errorf("cannot send message, err: %s\n", err)
+		}
+		return
+	}
+
+	switch message.Command() {
+	case "hudor":
+		h.run(message, h.handleActivate, RequireCreator, RequireBotAdmin)
+	case "settings":
+		h.run(message, h.handleSettings, RequireCreator)
+	case "addtrigger":
+		h.run(message, h.handleAddTrigger, RequireCreator)
+	case "listtriggers":
+		h.run(message, h.handleListTriggers, RequireCreator)
+	case "deltrigger":
+		h.run(message, h.handleDelTrigger, RequireCreator)
+	case "resetwarns":
+		h.run(message, h.handleResetWarns, RequireCreator)
+	}
+}
+
+// run builds an UpdateContext for message and executes handler wrapped
+// in RecoverPanic, the per-chat rate limiter, and any extra middlewares.
+func (h *commandHandler) run(message tgbotapi.Message, handler HandlerFunc, extra ...Middleware) {
+	ctx := &UpdateContext{Message: message, Bot: h.bot, Redis: h.redis}
+	middlewares := append([]Middleware{RecoverPanic, h.rateLimit}, extra...)
+
+	if err := chain(handler, middlewares...)(ctx); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"chat": message.Chat.ID,
+			"cmd":  message.Command(),
+		}).Errorf("command handler failed: %v", err)
+	}
+}
+
+// handleGlobalBan lets an owner manually add a bot to the federated
+// hudor:global:spammers set, e.g. ahead of it being auto-confirmed.
+func (h *commandHandler) handleGlobalBan(ctx *UpdateContext) error {
+	if !h.isOwner(ctx.Message.From.ID) {
+		return nil
+	}
+
+	botID, err := strconv.Atoi(strings.TrimSpace(ctx.Message.CommandArguments()))
+	if err != nil {
+		_, err := h.bot.Send(globalBanUsage(ctx.Message.Chat.ID, "gban"))
+		return err
+	}
+
+	if err := gbanUser(h.redis, botID); err != nil {
+		return err
+	}
+
+	_, err = h.bot.Send(globalBanAdded(ctx.Message.Chat.ID, botID))
+	return err
+}
+
+func (h *commandHandler) handleGlobalUnban(ctx *UpdateContext) error {
+	if !h.isOwner(ctx.Message.From.ID) {
+		return nil
+	}
+
+	botID, err := strconv.Atoi(strings.TrimSpace(ctx.Message.CommandArguments()))
+	if err != nil {
+		_, err := h.bot.Send(globalBanUsage(ctx.Message.Chat.ID, "gunban"))
+		return err
+	}
+
+	if err := gunbanUser(h.redis, botID); err != nil {
+		return err
+	}
+
+	_, err = h.bot.Send(globalBanRemoved(ctx.Message.Chat.ID, botID))
+	return err
+}
+
+func (h *commandHandler) handleGlobalStats(ctx *UpdateContext) error {
+	if !h.isOwner(ctx.Message.From.ID) {
+		return nil
+	}
+
+	count, err := globalSpammerCount(h.redis)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.bot.Send(globalStats(ctx.Message.Chat.ID, count))
+	return err
+}
+
+func (h *commandHandler) handleActivate(ctx *UpdateContext) error {
+	group := ctx.Group
+	if group == nil {
+		_, err := h.bot.Send(groupInformations(ctx.Message.Chat.ID, nil))
+		return err
+	}
+
+	if group.IsActive {
+		_, err := h.bot.Send(hodurAlreadyIsActive(ctx.Message.Chat.ID))
+		return err
+	}
+
+	if err := changeGroupActiveStatus(h.redis, ctx.Message.Chat.ID, true); err != nil {
+		return err
+	}
+
+	_, err := h.bot.Send(hudorActivated(ctx.Message.Chat.ID))
+	return err
+}
+
+// handleSettings shows the group's current settings, or, when called
+// with arguments, updates one of them:
+//
+//	/settings punishment <kick|ban|mute|mute_then_ban> [duration]
+//	/settings autoban <count>
+//	/settings automod <on|off>
+func (h *commandHandler) handleSettings(ctx *UpdateContext) error {
+	args := strings.Fields(ctx.Message.CommandArguments())
+	if len(args) == 0 {
+		_, err := h.bot.Send(groupInformations(ctx.Message.Chat.ID, ctx.Group))
+		return err
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "punishment":
+		return h.handleSettingsPunishment(ctx, args[1:])
+	case "autoban":
+		return h.handleSettingsAutoBan(ctx, args[1:])
+	case "automod":
+		return h.handleSettingsAutomod(ctx, args[1:])
+	}
+
+	_, err := h.bot.Send(settingsUsage(ctx.Message.Chat.ID))
+	return err
+}
+
+func (h *commandHandler) handleSettingsAutomod(ctx *UpdateContext, args []string) error {
+	if len(args) != 1 {
+		_, err := h.bot.Send(settingsUsage(ctx.Message.Chat.ID))
+		return err
+	}
+
+	var enabled bool
+	switch strings.ToLower(args[0]) {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		_, err := h.bot.Send(settingsInvalid(ctx.Message.Chat.ID, "مقدار باید on یا off باشد"))
+		return err
+	}
+
+	if err := setAutomodBuiltins(h.redis, ctx.Message.Chat.ID, enabled); err != nil {
+		return err
+	}
+
+	_, err := h.bot.Send(settingsUpdated(ctx.Message.Chat.ID))
+	return err
+}
+
+func (h *commandHandler) handleSettingsPunishment(ctx *UpdateContext, args []string) error {
+	if len(args) == 0 {
+		_, err := h.bot.Send(settingsUsage(ctx.Message.Chat.ID))
+		return err
+	}
+
+	mode := strings.ToLower(args[0])
+	if !validPunishmentMode(mode) {
+		_, err := h.bot.Send(settingsInvalid(ctx.Message.Chat.ID, "حالت باید یکی از kick, ban, mute, mute_then_ban باشد"))
+		return err
+	}
+
+	if len(args) > 1 {
+		duration, err := ParseCountableTime(args[1])
+		if err != nil {
+			_, sendErr := h.bot.Send(settingsInvalid(ctx.Message.Chat.ID, err.Error()))
+			return sendErr
+		}
+		if err := setMuteDuration(h.redis, ctx.Message.Chat.ID, int64(duration.Duration().Seconds())); err != nil {
+			return err
+		}
+	}
+
+	if err := setPunishmentMode(h.redis, ctx.Message.Chat.ID, mode); err != nil {
+		return err
+	}
+
+	_, err := h.bot.Send(settingsUpdated(ctx.Message.Chat.ID))
+	return err
+}
+
+func (h *commandHandler) handleSettingsAutoBan(ctx *UpdateContext, args []string) error {
+	if len(args) != 1 {
+		_, err := h.bot.Send(settingsUsage(ctx.Message.Chat.ID))
+		return err
+	}
+
+	count, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || count <= 0 {
+		_, sendErr := h.bot.Send(settingsInvalid(ctx.Message.Chat.ID, "تعداد باید عددی مثبت باشد"))
+		return sendErr
+	}
+
+	if err := setAutoBanAfter(h.redis, ctx.Message.Chat.ID, count); err != nil {
+		return err
+	}
+
+	_, err = h.bot.Send(settingsUpdated(ctx.Message.Chat.ID))
+	return err
+}
+
+// handleResetWarns forgives a member's invite warnings and auto-kick
+// history, identified by replying to one of their messages or passing
+// their numeric user ID.
+func (h *commandHandler) handleResetWarns(ctx *UpdateContext) error {
+	var userID int
+	if ctx.Message.ReplyToMessage != nil {
+		userID = ctx.Message.ReplyToMessage.From.ID
+	} else if arg := strings.TrimSpace(ctx.Message.CommandArguments()); arg != "" {
+		if id, err := strconv.Atoi(strings.TrimPrefix(arg, "@")); err == nil {
+			userID = id
+		}
+	}
+
+	if userID == 0 {
+		_, err := h.bot.Send(warnsResetUsage(ctx.Message.Chat.ID))
+		return err
+	}
+
+	if err := resetMemberWarns(h.redis, ctx.Message.Chat.ID, userID); err != nil {
+		return err
+	}
+
+	_, err := h.bot.Send(warnsReset(ctx.Message.Chat.ID, userID))
+	return err
+}
+
+func (h *commandHandler) handleAddTrigger(ctx *UpdateContext) error {
+	args := strings.SplitN(strings.TrimSpace(ctx.Message.CommandArguments()), " ", 2)
+	if len(args) != 2 {
+		_, err := h.bot.Send(triggerUsage(ctx.Message.Chat.ID))
+		return err
+	}
+
+	pattern, action := args[0], TriggerAction(strings.TrimSpace(args[1]))
+	if !validTriggerAction(action) {
+		_, err := h.bot.Send(triggerInvalid(ctx.Message.Chat.ID, "اکشن باید یکی از warn, delete, mute, kick باشد"))
+		return err
+	}
+
+	id, err := addTrigger(h.redis, ctx.Message.Chat.ID, pattern, action)
+	if err != nil {
+		_, sendErr := h.bot.Send(triggerInvalid(ctx.Message.Chat.ID, err.Error()))
+		return sendErr
+	}
+
+	_, err = h.bot.Send(triggerAdded(ctx.Message.Chat.ID, id))
+	return err
+}
+
+func (h *commandHandler) handleListTriggers(ctx *UpdateContext) error {
+	triggers, err := listTriggers(h.redis, ctx.Message.Chat.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.bot.Send(triggerList(ctx.Message.Chat.ID, triggers))
+	return err
+}
+
+func (h *commandHandler) handleDelTrigger(ctx *UpdateContext) error {
+	id := strings.TrimSpace(ctx.Message.CommandArguments())
+	if id == "" {
+		_, err := h.bot.Send(triggerUsage(ctx.Message.Chat.ID))
+		return err
+	}
+
+	if err := deleteTrigger(h.redis, ctx.Message.Chat.ID, id); err != nil {
+		return err
+	}
+
+	_, err := h.bot.Send(triggerDeleted(ctx.Message.Chat.ID, id))
+	return err
+}
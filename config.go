@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// loadIDSetFromEnv parses a comma-separated list of integer Telegram
+// user IDs from an environment variable into a lookup set. Used for the
+// owner and superuser bypass lists.
+func loadIDSetFromEnv(name string) map[int]bool {
+	ids := make(map[int]bool)
+	for _, raw := range strings.Split(os.Getenv(name), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			continue
+		}
+		ids[id] = true
+	}
+	return ids
+}
+
+// superusers bypass normal per-group creator checks, e.g. for support
+// and debugging in any chat hudorBot is in.
+var superusers = loadIDSetFromEnv("HUDOR_SUPERUSER_IDS")
+
+func isSuperuser(userID int) bool {
+	return superusers[userID]
+}
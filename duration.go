@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CountableTime is a duration accepted from chat commands such as
+// /settings, extending time.ParseDuration with a "d" (day) unit so
+// admins can write "1d" instead of "24h".
+type CountableTime time.Duration
+
+func ParseCountableTime(raw string) (CountableTime, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	if strings.HasSuffix(raw, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(raw, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid day duration %q: %v", raw, err)
+		}
+		return CountableTime(time.Duration(days) * 24 * time.Hour), nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %v", raw, err)
+	}
+	return CountableTime(d), nil
+}
+
+func (c CountableTime) Duration() time.Duration {
+	return time.Duration(c)
+}
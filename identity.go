@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"strings"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// BotIdentity caches the bot's own username so multi-bot command
+// routing ("/cmd@botusername") is a single case-insensitive lookup per
+// update instead of touching bot.Self on every check.
+type BotIdentity struct {
+	username string
+}
+
+func NewBotIdentity(bot *tgbotapi.BotAPI) BotIdentity {
+	return BotIdentity{username: strings.ToLower(bot.Self.UserName)}
+}
+
+// commandWithAt returns the "cmd" or "cmd@username" token at the start
+// of a command message's text, without the leading slash.
+func commandWithAt(text string) string {
+	if !strings.HasPrefix(text, "/") {
+		return ""
+	}
+	fields := strings.SplitN(text, " ", 2)
+	return strings.TrimPrefix(fields[0], "/")
+}
+
+// AddressedToUs reports whether a "/cmd@username"-style command targets
+// this bot. Commands without an @suffix are always considered ours.
+func (b BotIdentity) AddressedToUs(commandWithAt string) bool {
+	i := strings.Index(commandWithAt, "@")
+	if i == -1 {
+		return true
+	}
+	return strings.EqualFold(commandWithAt[i+1:], b.username)
+}
+
+// stripBotMention removes a "@botusername" suffix from a command
+// message's text so downstream parsing (Command, CommandArguments)
+// doesn't need to know about username-scoped routing.
+func stripBotMention(text string) string {
+	fields := strings.SplitN(text, " ", 2)
+	if i := strings.Index(fields[0], "@"); i != -1 {
+		fields[0] = fields[0][:i]
+	}
+	return strings.Join(fields, " ")
+}
@@ -2,6 +2,7 @@ package bot
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/go-telegram-bot-api/telegram-bot-api"
 )
@@ -11,6 +12,11 @@ func warnUser(chatID int64, current int64, limit int64) tgbotapi.MessageConfig {
 	return tgbotapi.NewMessage(chatID, text)
 }
 
+func triggerWarnUser(chatID int64, current int64, limit int64) tgbotapi.MessageConfig {
+	text := fmt.Sprintf("⚠️ اخطار %d از %d ⚠️\nپیام شما قوانین خودکار گروه را نقض کرد.", current, limit)
+	return tgbotapi.NewMessage(chatID, text)
+}
+
 func superGroupIntroduction(chatID int64) tgbotapi.MessageConfig {
 	text := `سلام 👋
 	من هودورم، وظیفه من محافظت 🛡 از گروه‌ها در برابر ربات‌های اسپمر هست.
@@ -91,6 +97,87 @@ func hodurOnlyActiveInSuperGroups(chatID int64) tgbotapi.MessageConfig {
 	return tgbotapi.NewMessage(chatID, text)
 }
 
+func globalBanAdded(chatID int64, botID int) tgbotapi.MessageConfig {
+	text := fmt.Sprintf("✅ بات %d به لیست سیاه سراسری افزوده شد.", botID)
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func globalBanRemoved(chatID int64, botID int) tgbotapi.MessageConfig {
+	text := fmt.Sprintf("✅ بات %d از لیست سیاه سراسری حذف شد.", botID)
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func globalStats(chatID int64, count int64) tgbotapi.MessageConfig {
+	text := fmt.Sprintf("🌐 تعداد ربات‌های اسپمر شناخته‌شده سراسری: %d", count)
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func globalBanUsage(chatID int64, cmd string) tgbotapi.MessageConfig {
+	text := fmt.Sprintf("استفاده: /%s <botid>", cmd)
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func triggerAdded(chatID int64, id string) tgbotapi.MessageConfig {
+	text := fmt.Sprintf("✅ قانون خودکار با شناسه %s اضافه شد.", id)
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func triggerDeleted(chatID int64, id string) tgbotapi.MessageConfig {
+	text := fmt.Sprintf("✅ قانون خودکار %s حذف شد.", id)
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func triggerUsage(chatID int64) tgbotapi.MessageConfig {
+	text := `استفاده: /addtrigger <regex> <warn|delete|mute|kick>`
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func triggerInvalid(chatID int64, reason string) tgbotapi.MessageConfig {
+	text := fmt.Sprintf("❌ قانون نامعتبر: %s", reason)
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func triggerList(chatID int64, triggers []Trigger) tgbotapi.MessageConfig {
+	if len(triggers) == 0 {
+		return tgbotapi.NewMessage(chatID, "هیچ قانون خودکاری برای این گروه تعریف نشده.")
+	}
+
+	var b strings.Builder
+	b.WriteString("📋 قوانین خودکار:\n")
+	for _, t := range triggers {
+		fmt.Fprintf(&b, "%s: /%s/ → %s\n", t.ID, t.Regex, t.Action)
+	}
+	return tgbotapi.NewMessage(chatID, b.String())
+}
+
+func warnsResetUsage(chatID int64) tgbotapi.MessageConfig {
+	text := "استفاده: روی پیام کاربر ریپلای کنین و /resetwarns رو بزنین، یا /resetwarns <user_id>"
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func warnsReset(chatID int64, userID int) tgbotapi.MessageConfig {
+	text := fmt.Sprintf("✅ اخطارهای کاربر %d بخشیده شد.", userID)
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func settingsUsage(chatID int64) tgbotapi.MessageConfig {
+	text := `استفاده:
+/settings punishment <kick|ban|mute|mute_then_ban> [duration]
+/settings autoban <count>
+/settings automod <on|off>`
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func settingsInvalid(chatID int64, reason string) tgbotapi.MessageConfig {
+	text := fmt.Sprintf("❌ تنظیمات نامعتبر: %s", reason)
+	return tgbotapi.NewMessage(chatID, text)
+}
+
+func settingsUpdated(chatID int64) tgbotapi.MessageConfig {
+	text := "✅ تنظیمات گروه به‌روزرسانی شد."
+	return tgbotapi.NewMessage(chatID, text)
+}
+
 func groupInformations(chatID int64, group *groupSettings) tgbotapi.MessageConfig {
 	var text string
 	if group == nil {
@@ -111,10 +198,19 @@ func groupInformations(chatID int64, group *groupSettings) tgbotapi.MessageConfi
 			warnStatus = "🚫 غیر فعال 🚫"
 		}
 
+		var automodStatus string
+		if group.AutomodBuiltins {
+			automodStatus = "❇️ فعال ❇️"
+		} else {
+			automodStatus = "🚫 غیر فعال 🚫"
+		}
+
 		text = fmt.Sprintf(`گروه: %s
 		وضعیت فعالیت: %s
 		نمایش اخطار: %s
-		تعداد اخطار قبل از حذف کاربر: %d بار`, group.Title, activeStatus, warnStatus, group.Limit)
+		تعداد اخطار قبل از حذف کاربر: %d بار
+		تعداد اخراج خودکار قبل از بن دائم: %d بار
+		قوانین خودکار هودور (لینک دعوت/فوروارد): %s`, group.Title, activeStatus, warnStatus, group.Limit, group.AutoBanAfter, automodStatus)
 	}
 
 	return tgbotapi.NewMessage(chatID, text)
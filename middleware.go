@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/sirupsen/logrus"
+)
+
+// UpdateContext carries the per-update state threaded through a
+// middleware chain, shared by both BotService.Start and commandHandler.
+type UpdateContext struct {
+	Message tgbotapi.Message
+	Bot     *tgbotapi.BotAPI
+	Redis   *redis.Client
+	Group   *groupSettings
+}
+
+// HandlerFunc is the terminal handler at the end of a middleware chain.
+type HandlerFunc func(ctx *UpdateContext) error
+
+// Middleware can inspect/short-circuit an update before it reaches next.
+type Middleware func(ctx *UpdateContext, next HandlerFunc) error
+
+// chain composes middlewares around handler, running in the order given.
+func chain(handler HandlerFunc, middlewares ...Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		mw := middlewares[i]
+		next := handler
+		handler = func(ctx *UpdateContext) error {
+			return mw(ctx, next)
+		}
+	}
+	return handler
+}
+
+// RequireSuperGroup rejects updates coming from anywhere but a super group.
+func RequireSuperGroup(ctx *UpdateContext, next HandlerFunc) error {
+	if !ctx.Message.Chat.IsSuperGroup() {
+		_, err := ctx.Bot.Send(hodurOnlyActiveInSuperGroups(ctx.Message.Chat.ID))
+		return err
+	}
+	return next(ctx)
+}
+
+// RequireCreator loads the group's settings into ctx.Group and rejects
+// the update unless it comes from the group creator or a superuser.
+func RequireCreator(ctx *UpdateContext, next HandlerFunc) error {
+	group, err := findGroupByID(ctx.Redis, ctx.Message.Chat.ID)
+	if err != nil {
+		return err
+	}
+	ctx.Group = group
+
+	if isSuperuser(ctx.Message.From.ID) {
+		return next(ctx)
+	}
+
+	if group == nil || ctx.Message.From.ID != group.Creator {
+		_, err := ctx.Bot.Send(hudorCanOnlySendFromCreator(ctx.Message.Chat.ID))
+		return err
+	}
+	return next(ctx)
+}
+
+// RequireBotAdmin checks hudorBot has ban permission before dispatch,
+// instead of discovering the failure only after a kick attempt fails.
+func RequireBotAdmin(ctx *UpdateContext, next HandlerFunc) error {
+	member, err := ctx.Bot.GetChatMember(tgbotapi.ChatConfigWithUser{
+		ChatID: ctx.Message.Chat.ID,
+		UserID: ctx.Bot.Self.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !member.CanRestrictMembers {
+		_, err := ctx.Bot.Send(errorBotIsNotAdmin(ctx.Message.Chat.ID))
+		return err
+	}
+	return next(ctx)
+}
+
+// RecoverPanic stops a panic in a handler from crashing the update loop.
+func RecoverPanic(ctx *UpdateContext, next HandlerFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.WithField("chat", ctx.Message.Chat.ID).Errorf("recovered from panic: %v", r)
+			err = fmt.Errorf("recovered from panic: %v", r)
+		}
+	}()
+	return next(ctx)
+}
+
+type rateBucket struct {
+	tokens  int
+	resetAt time.Time
+}
+
+// RateLimit returns a per-chat token bucket middleware so a single
+// flooding chat can't starve update processing for everyone else.
+func RateLimit(limit int, window time.Duration) Middleware {
+	var mu sync.Mutex
+	buckets := make(map[int64]*rateBucket)
+
+	return func(ctx *UpdateContext, next HandlerFunc) error {
+		mu.Lock()
+		b, ok := buckets[ctx.Message.Chat.ID]
+		if !ok || time.Now().After(b.resetAt) {
+			b = &rateBucket{tokens: limit, resetAt: time.Now().Add(window)}
+			buckets[ctx.Message.Chat.ID] = b
+		}
+		allowed := b.tokens > 0
+		if allowed {
+			b.tokens--
+		}
+		mu.Unlock()
+
+		if !allowed {
+			logrus.WithField("chat", ctx.Message.Chat.ID).Warn("rate limit exceeded, dropping update")
+			return nil
+		}
+		return next(ctx)
+	}
+}
@@ -0,0 +1,322 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// globalSpammerTTL bounds how long a sighting is remembered; bots that
+// stop getting reported eventually age out of the federated blacklist.
+const globalSpammerTTL = 30 * 24 * time.Hour
+
+// globalSpammerMinSightings and globalSpammerMinGroups are the
+// confirmation thresholds (N sightings across M distinct groups) a bot
+// must cross before it's added to the federated set.
+const (
+	globalSpammerMinSightings = 5
+	globalSpammerMinGroups    = 3
+)
+
+func groupKey(chatID int64) string {
+	return fmt.Sprintf("hudor:group:%d", chatID)
+}
+
+func adminKey(userID int) string {
+	return fmt.Sprintf("hudor:admin:%d", userID)
+}
+
+func whiteListKey(chatID int64) string {
+	return fmt.Sprintf("hudor:whitelist:%d", chatID)
+}
+
+// isWhitelisted reports whether userID is on chatID's per-group
+// whitelist, shared by every path that needs to exempt an
+// already-approved bot or user from automod/punishment.
+func isWhitelisted(r *redis.Client, chatID int64, userID int) (bool, error) {
+	return r.SIsMember(whiteListKey(chatID), userID).Result()
+}
+
+func membersKey(chatID int64, userID int) string {
+	return fmt.Sprintf("hudor:members:%d:%d", chatID, userID)
+}
+
+// globalSpammersKey holds the federated set of confirmed spam bot IDs,
+// shared across every group hudorBot protects.
+func globalSpammersKey() string {
+	return "hudor:global:spammers"
+}
+
+// PunishmentMode values for groupSettings.PunishmentMode.
+const (
+	PunishmentKick        = "kick"
+	PunishmentBan         = "ban"
+	PunishmentMute        = "mute"
+	PunishmentMuteThenBan = "mute_then_ban"
+)
+
+// validPunishmentMode reports whether mode is one of the punishment
+// modes settable via /settings.
+func validPunishmentMode(mode string) bool {
+	switch mode {
+	case PunishmentKick, PunishmentBan, PunishmentMute, PunishmentMuteThenBan:
+		return true
+	}
+	return false
+}
+
+// defaultMuteDuration is used when a group hasn't configured one.
+const defaultMuteDuration = 600 // seconds
+
+// defaultAutoBanAfter is how many auto-kick events an inviter can cause
+// within the warn window before a group escalates to a permanent ban.
+const defaultAutoBanAfter = 3
+
+type groupSettings struct {
+	IsActive          bool
+	ShowWarn          bool
+	Limit             int64
+	Creator           int
+	Title             string
+	Description       string
+	RespectGlobalBans bool
+	PunishmentMode    string
+	MuteDuration      int64
+	AutoBanAfter      int64
+	AutomodBuiltins   bool
+}
+
+// Map converts the settings into the flat field set stored via HMSet.
+func (g groupSettings) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"isActive":          g.IsActive,
+		"showWarn":          g.ShowWarn,
+		"limit":             g.Limit,
+		"creator":           g.Creator,
+		"title":             g.Title,
+		"description":       g.Description,
+		"respectGlobalBans": g.RespectGlobalBans,
+		"punishmentMode":    g.PunishmentMode,
+		"muteDuration":      g.MuteDuration,
+		"autoBanAfter":      g.AutoBanAfter,
+		"automodBuiltins":   g.AutomodBuiltins,
+	}
+}
+
+func findGroupByID(r *redis.Client, chatID int64) (*groupSettings, error) {
+	fields, err := r.HGetAll(groupKey(chatID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	limit, _ := strconv.ParseInt(fields["limit"], 10, 64)
+	creator, _ := strconv.Atoi(fields["creator"])
+
+	punishmentMode := fields["punishmentMode"]
+	if punishmentMode == "" {
+		punishmentMode = PunishmentKick
+	}
+
+	muteDuration, err := strconv.ParseInt(fields["muteDuration"], 10, 64)
+	if err != nil || muteDuration == 0 {
+		muteDuration = defaultMuteDuration
+	}
+
+	autoBanAfter, err := strconv.ParseInt(fields["autoBanAfter"], 10, 64)
+	if err != nil || autoBanAfter == 0 {
+		autoBanAfter = defaultAutoBanAfter
+	}
+
+	return &groupSettings{
+		IsActive:          fields["isActive"] == "1",
+		ShowWarn:          fields["showWarn"] == "1",
+		Limit:             limit,
+		Creator:           creator,
+		Title:             fields["title"],
+		Description:       fields["description"],
+		RespectGlobalBans: fields["respectGlobalBans"] != "0",
+		PunishmentMode:    punishmentMode,
+		MuteDuration:      muteDuration,
+		AutoBanAfter:      autoBanAfter,
+		AutomodBuiltins:   fields["automodBuiltins"] == "1",
+	}, nil
+}
+
+func isGroupActive(r *redis.Client, chatID int64) (bool, error) {
+	active, err := r.HGet(groupKey(chatID), "isActive").Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return active == "1", nil
+}
+
+func changeGroupActiveStatus(r *redis.Client, chatID int64, active bool) error {
+	return r.HSet(groupKey(chatID), "isActive", active).Err()
+}
+
+// setPunishmentMode persists the punishment mode a group's automod
+// escalates to once a user's warnings reach its limit.
+func setPunishmentMode(r *redis.Client, chatID int64, mode string) error {
+	return r.HSet(groupKey(chatID), "punishmentMode", mode).Err()
+}
+
+// setMuteDuration persists how long PunishmentMute/PunishmentMuteThenBan
+// restrict a user for, in seconds.
+func setMuteDuration(r *redis.Client, chatID int64, seconds int64) error {
+	return r.HSet(groupKey(chatID), "muteDuration", seconds).Err()
+}
+
+// setAutoBanAfter persists how many auto-kick events an inviter can
+// cause before a group escalates to a permanent ban.
+func setAutoBanAfter(r *redis.Client, chatID int64, count int64) error {
+	return r.HSet(groupKey(chatID), "autoBanAfter", count).Err()
+}
+
+// setAutomodBuiltins toggles the built-in content triggers (forwarded
+// channel posts, invite links) on top of a group's own /addtrigger
+// rules, which always run regardless of this setting.
+func setAutomodBuiltins(r *redis.Client, chatID int64, enabled bool) error {
+	return r.HSet(groupKey(chatID), "automodBuiltins", enabled).Err()
+}
+
+// warnTTL lets invite warnings decay: a user who invited one spam bot
+// months ago isn't permanently one step away from a ban.
+const warnTTL = 24 * time.Hour
+
+// incrCounterWithTTL increments key and, the first time it's touched,
+// sets it to expire after ttl, so decaying counters (invite warns,
+// trigger warns) don't need to repeat the "set TTL once" dance.
+func incrCounterWithTTL(r *redis.Client, key string, ttl time.Duration) (int64, error) {
+	count, err := r.Incr(key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := r.Expire(key, ttl).Err(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+func incrementMemberWarns(r *redis.Client, chatID int64, userID int) (int64, error) {
+	return incrCounterWithTTL(r, membersKey(chatID, userID), warnTTL)
+}
+
+func resetMemberWarns(r *redis.Client, chatID int64, userID int) error {
+	pipe := r.Pipeline()
+	pipe.Del(membersKey(chatID, userID))
+	pipe.Del(autoKickHistoryKey(chatID, userID))
+	_, err := pipe.Exec()
+	return err
+}
+
+func autoKickHistoryKey(chatID int64, userID int) string {
+	return fmt.Sprintf("hudor:autokick:%d:%d", chatID, userID)
+}
+
+// recordAutoKick appends an auto-kick event to the user's history and
+// reports how many events remain within window, pruning older ones so
+// the count reflects only recent behavior.
+func recordAutoKick(r *redis.Client, chatID int64, userID int, window time.Duration) (int64, error) {
+	key := autoKickHistoryKey(chatID, userID)
+	now := time.Now().UnixNano()
+
+	pipe := r.Pipeline()
+	pipe.ZAdd(key, redis.Z{Score: float64(now), Member: now})
+	pipe.ZRemRangeByScore(key, "-inf", strconv.FormatInt(now-window.Nanoseconds(), 10))
+	count := pipe.ZCard(key)
+	pipe.Expire(key, window)
+
+	if _, err := pipe.Exec(); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+func muteOffenseKey(chatID int64, userID int) string {
+	return fmt.Sprintf("hudor:mute-offense:%d:%d", chatID, userID)
+}
+
+// recordMuteOffense counts how many times a user has been muted within
+// the mute window, for mute_then_ban escalation to a permanent ban.
+func recordMuteOffense(r *redis.Client, chatID int64, userID int, window time.Duration) (int64, error) {
+	key := muteOffenseKey(chatID, userID)
+	count, err := r.Incr(key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := r.Expire(key, window).Err(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+func globalSpammerSightingsKey(botID int) string {
+	return fmt.Sprintf("hudor:global:spammer:%d:sightings", botID)
+}
+
+func globalSpammerGroupsKey(botID int) string {
+	return fmt.Sprintf("hudor:global:spammer:%d:groups", botID)
+}
+
+// recordGlobalSpammerSighting registers that botID was kicked as a spam
+// bot in chatID, refreshing the sighting TTL, and promotes the bot to
+// the federated hudor:global:spammers set once it crosses the
+// confirmation thresholds.
+func recordGlobalSpammerSighting(r *redis.Client, botID int, chatID int64) error {
+	sightingsKey := globalSpammerSightingsKey(botID)
+	groupsKey := globalSpammerGroupsKey(botID)
+
+	pipe := r.Pipeline()
+	sightings := pipe.Incr(sightingsKey)
+	pipe.Expire(sightingsKey, globalSpammerTTL)
+	pipe.SAdd(groupsKey, chatID)
+	pipe.Expire(groupsKey, globalSpammerTTL)
+	groups := pipe.SCard(groupsKey)
+	if _, err := pipe.Exec(); err != nil {
+		return err
+	}
+
+	if sightings.Val() >= globalSpammerMinSightings && groups.Val() >= globalSpammerMinGroups {
+		return r.SAdd(globalSpammersKey(), botID).Err()
+	}
+
+	return nil
+}
+
+func isGlobalSpammer(r *redis.Client, botID int) (bool, error) {
+	return r.SIsMember(globalSpammersKey(), botID).Result()
+}
+
+func gbanUser(r *redis.Client, botID int) error {
+	return r.SAdd(globalSpammersKey(), botID).Err()
+}
+
+func gunbanUser(r *redis.Client, botID int) error {
+	return r.SRem(globalSpammersKey(), botID).Err()
+}
+
+func globalSpammerCount(r *redis.Client) (int64, error) {
+	return r.SCard(globalSpammersKey()).Result()
+}
+
+func findCreator(admins []tgbotapi.ChatMember) *tgbotapi.User {
+	for _, admin := range admins {
+		if admin.Status == "creator" {
+			return admin.User
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// TriggerAction is what happens to a message/user when a trigger fires.
+type TriggerAction string
+
+const (
+	TriggerWarn   TriggerAction = "warn"
+	TriggerDelete TriggerAction = "delete"
+	TriggerMute   TriggerAction = "mute"
+	TriggerKick   TriggerAction = "kick"
+)
+
+func validTriggerAction(action TriggerAction) bool {
+	switch action {
+	case TriggerWarn, TriggerDelete, TriggerMute, TriggerKick:
+		return true
+	}
+	return false
+}
+
+// Trigger is a single group-configured automod rule: if Regex matches a
+// message's text, Action is applied.
+type Trigger struct {
+	ID     string
+	Regex  string
+	Action TriggerAction
+}
+
+func triggersKey(chatID int64) string {
+	return fmt.Sprintf("hudor:group:%d:triggers", chatID)
+}
+
+func addTrigger(r *redis.Client, chatID int64, pattern string, action TriggerAction) (string, error) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+
+	id := strconv.FormatInt(time.Now().UnixNano(), 36)
+	value := pattern + "|" + string(action)
+	if err := r.HSet(triggersKey(chatID), id, value).Err(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func listTriggers(r *redis.Client, chatID int64) ([]Trigger, error) {
+	fields, err := r.HGetAll(triggersKey(chatID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	triggers := make([]Trigger, 0, len(fields))
+	for id, value := range fields {
+		parts := strings.SplitN(value, "|", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		triggers = append(triggers, Trigger{ID: id, Regex: parts[0], Action: TriggerAction(parts[1])})
+	}
+	return triggers, nil
+}
+
+func deleteTrigger(r *redis.Client, chatID int64, id string) error {
+	return r.HDel(triggersKey(chatID), id).Err()
+}
+
+func triggerWarnsKey(chatID int64, userID int) string {
+	return fmt.Sprintf("hudor:trigger-warns:%d:%d", chatID, userID)
+}
+
+// incrementTriggerWarns counts content-trigger warnings in their own
+// namespace, kept separate from incrementMemberWarns's invite-warn
+// counter so tripping a text trigger can't contribute to an auto-kick
+// meant for inviting spam bots.
+func incrementTriggerWarns(r *redis.Client, chatID int64, userID int) (int64, error) {
+	return incrCounterWithTTL(r, triggerWarnsKey(chatID, userID), warnTTL)
+}
+
+// TriggerContext carries what a TriggerMatcher needs to inspect a message.
+type TriggerContext struct {
+	Message tgbotapi.Message
+	Redis   *redis.Client
+}
+
+// TriggerMatcher inspects a message and, if it fires, reports the
+// action that should be taken against it.
+type TriggerMatcher func(ctx *TriggerContext) (fired bool, action TriggerAction)
+
+// TriggerSet is the ordered list of matchers evaluated per message; the
+// first one to fire wins.
+type TriggerSet []TriggerMatcher
+
+// DefaultTriggerSet is the group's own /addtrigger rules, always
+// evaluated since a creator configured them explicitly.
+func DefaultTriggerSet() TriggerSet {
+	return TriggerSet{
+		RegexTriggerMatcher,
+	}
+}
+
+// BuiltinTriggerSet is hudorBot's own content heuristics (forwarded
+// channel posts, invite links). Unlike DefaultTriggerSet these are
+// opt-in per group via groupSettings.AutomodBuiltins, since they act on
+// ordinary conversation and can false-positive.
+func BuiltinTriggerSet() TriggerSet {
+	return TriggerSet{
+		ForwardedChannelMatcher,
+		InviteLinkMatcher,
+	}
+}
+
+// RegexTriggerMatcher evaluates the group's own /addtrigger rules.
+func RegexTriggerMatcher(ctx *TriggerContext) (bool, TriggerAction) {
+	triggers, err := listTriggers(ctx.Redis, ctx.Message.Chat.ID)
+	if err != nil {
+		return false, ""
+	}
+
+	for _, t := range triggers {
+		if matched, err := regexp.MatchString(t.Regex, ctx.Message.Text); err == nil && matched {
+			return true, t.Action
+		}
+	}
+	return false, ""
+}
+
+// ForwardedChannelMatcher fires on messages forwarded from a channel,
+// a common vector for ad spam relayed through compromised accounts.
+func ForwardedChannelMatcher(ctx *TriggerContext) (bool, TriggerAction) {
+	if ctx.Message.ForwardFromChat != nil && ctx.Message.ForwardFromChat.IsChannel() {
+		return true, TriggerDelete
+	}
+	return false, ""
+}
+
+var inviteLinkPattern = regexp.MustCompile(`(?:https?://)?t(?:elegram)?\.me/(?:joinchat/|\+)\S+`)
+
+// InviteLinkMatcher fires on t.me/joinchat/... and t.me/+... invite
+// links, not on ordinary @mentions or channel post links.
+func InviteLinkMatcher(ctx *TriggerContext) (bool, TriggerAction) {
+	if inviteLinkPattern.MatchString(ctx.Message.Text) {
+		return true, TriggerDelete
+	}
+	return false, ""
+}
+
+func joinFloodKey(chatID int64) string {
+	return fmt.Sprintf("hudor:group:%d:joins", chatID)
+}
+
+const (
+	joinFloodWindow    = 60 * time.Second
+	joinFloodThreshold = 5
+)
+
+// recordJoinFlood logs `count` joins happening now and reports whether
+// the group has seen >= joinFloodThreshold joins within joinFloodWindow.
+func recordJoinFlood(r *redis.Client, chatID int64, count int) (bool, error) {
+	key := joinFloodKey(chatID)
+	now := time.Now()
+	cutoff := now.Add(-joinFloodWindow).UnixNano()
+
+	pipe := r.Pipeline()
+	for i := 0; i < count; i++ {
+		member := now.UnixNano() + int64(i)
+		pipe.ZAdd(key, redis.Z{Score: float64(member), Member: member})
+	}
+	pipe.ZRemRangeByScore(key, "-inf", strconv.FormatInt(cutoff, 10))
+	total := pipe.ZCard(key)
+	pipe.Expire(key, joinFloodWindow)
+
+	if _, err := pipe.Exec(); err != nil {
+		return false, err
+	}
+
+	return total.Val() >= joinFloodThreshold, nil
+}